@@ -0,0 +1,193 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/cio"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/containerd/containerd/oci"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"google.golang.org/grpc"
+
+	"gopkg.in/bblfsh/sdk.v2/protocol"
+)
+
+const (
+	// containerdSocket is the default path to the containerd API socket.
+	containerdSocket = "/run/containerd/containerd.sock"
+	// containerdNamespace isolates the containers created by the SDK from
+	// the rest of the images/containers managed on the host.
+	containerdNamespace = "bblfsh-sdk"
+)
+
+// containerdLogLines is how many trailing log lines are kept in memory for
+// Tail and for the replay DumpLogs/StreamLogs do on top of live output,
+// since containerd (unlike the Docker daemon) doesn't keep its own buffer.
+const containerdLogLines = 10000
+
+// containerdInstance runs bblfshd as a containerd task, talking to the
+// containerd API socket directly instead of a Docker daemon.
+type containerdInstance struct {
+	cli     *containerd.Client
+	task    containerd.Task
+	taskCtx context.Context
+	user    *grpc.ClientConn
+	addr    string
+	logs    *logHub
+}
+
+// runWithDriverContainerd starts a bblfshd task on containerd and installs
+// the specified driver to it.
+func runWithDriverContainerd(lang, id string) (*containerdInstance, error) {
+	cli, err := containerd.New(containerdSocket)
+	if err != nil {
+		return nil, fmt.Errorf("build: connecting to containerd: %v", err)
+	}
+	ctx := namespaces.WithNamespace(context.Background(), containerdNamespace)
+
+	const bblfshdImage = "docker.io/bblfsh/bblfshd:latest"
+	printCommand("ctr", "run", "-d", "--privileged", bblfshdImage, id)
+	image, err := cli.Pull(ctx, bblfshdImage, containerd.WithPullUnpack)
+	if err != nil {
+		cli.Close()
+		return nil, fmt.Errorf("build: pulling %s: %v", bblfshdImage, err)
+	}
+
+	container, err := cli.NewContainer(ctx, id,
+		containerd.WithNewSnapshot(id+"-snapshot", image),
+		containerd.WithNewSpec(
+			oci.WithImageConfig(image),
+			oci.WithPrivileged,
+			// This backend doesn't ship a CNI config, so rather than leave
+			// the task in an isolated netns that ClientV1 can't reach, put
+			// it in the host's network namespace and dial it on loopback,
+			// the same reachability story the Docker backend gets from
+			// -p/--net=host style setups.
+			oci.WithHostNamespace(specs.NetworkNamespace),
+		),
+	)
+	if err != nil {
+		cli.Close()
+		return nil, fmt.Errorf("build: creating container: %v", err)
+	}
+
+	logs := newLogHub(containerdLogLines)
+	task, err := container.NewTask(ctx, cio.NewCreator(cio.WithStreams(nil, logs, logs)))
+	if err != nil {
+		container.Delete(ctx)
+		cli.Close()
+		return nil, fmt.Errorf("build: creating task: %v", err)
+	}
+	if err := task.Start(ctx); err != nil {
+		task.Delete(ctx)
+		container.Delete(ctx)
+		cli.Close()
+		return nil, fmt.Errorf("build: starting task: %v", err)
+	}
+
+	// The task shares the host's network namespace (see WithHostNamespace
+	// above), so bblfshd is reachable on loopback without resolving a
+	// container IP.
+	s := &containerdInstance{cli: cli, task: task, taskCtx: ctx, addr: "127.0.0.1", logs: logs}
+
+	installCtx, cancel := context.WithTimeout(ctx, time.Minute*3)
+	defer cancel()
+	if err := s.install(installCtx, lang, id); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// install runs `bblfshctl driver install` inside the task via a containerd
+// exec process. It resolves id through resolveDriverRefRegistry rather than
+// resolveDriverRef, since this backend has no Docker daemon for a bare id to
+// fall back to. Local docker-archive:/oci-archive: references aren't
+// supported here yet: unlike the Docker backend, which bind-mounts the
+// resolved archive into the container (see dev.go), this backend has no
+// equivalent hook, so such a reference would fail inside the task with a
+// file-not-found error; reject it up front instead.
+func (s *containerdInstance) install(ctx context.Context, lang, id string) error {
+	ref, err := resolveDriverRefRegistry(id)
+	if err != nil {
+		return err
+	}
+	if _, _, ok := archiveRefPath(ref); ok {
+		return fmt.Errorf("build: %s: local driver archives are not supported on the containerd runtime", id)
+	}
+
+	spec, err := s.task.Spec(ctx)
+	if err != nil {
+		return err
+	}
+	cmd := []string{"bblfshctl", "driver", "install", lang, ref}
+	pspec := spec.Process
+	pspec.Args = cmd
+
+	proc, err := s.task.Exec(ctx, "install-driver", pspec, cio.NewCreator(cio.WithStdio))
+	if err != nil {
+		return err
+	}
+	defer proc.Delete(ctx)
+
+	statusC, err := proc.Wait(ctx)
+	if err != nil {
+		return err
+	}
+	if err := proc.Start(ctx); err != nil {
+		return err
+	}
+	status := <-statusC
+	if code, _, err := status.Result(); err != nil {
+		return err
+	} else if code != 0 {
+		return fmt.Errorf("build: bblfshctl driver install exited with code %d", code)
+	}
+	return nil
+}
+
+func (s *containerdInstance) ClientV1(ctx context.Context) (protocol.ProtocolServiceClient, error) {
+	if s.user == nil {
+		conn, err := grpc.DialContext(ctx, s.addr+":"+cliPort, grpc.WithInsecure(), grpc.WithBlock())
+		if err != nil {
+			return nil, err
+		}
+		s.user = conn
+	}
+	return protocol.NewProtocolServiceClient(s.user), nil
+}
+
+func (s *containerdInstance) DumpLogs(w io.Writer) error {
+	for _, line := range s.logs.tail(containerdLogLines) {
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *containerdInstance) StreamLogs(ctx context.Context, w io.Writer, opts LogOptions) error {
+	return s.logs.stream(ctx, w, opts)
+}
+
+func (s *containerdInstance) Tail(n int, w io.Writer) error {
+	for _, line := range s.logs.tail(n) {
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *containerdInstance) Close() error {
+	if s.user != nil {
+		_ = s.user.Close()
+	}
+	ctx := s.taskCtx
+	_, _ = s.task.Delete(ctx, containerd.WithProcessKill)
+	return s.cli.Close()
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"errors"
 	"io"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,16 +18,23 @@ import (
 const (
 	cliPort      = "9432"
 	dockerSchema = "docker-daemon:"
+	// archiveMountDir is where a local driver archive resolved by
+	// resolveDriverRef is bind-mounted inside the bblfshd container, since
+	// bblfshctl runs there and has no access to the host filesystem otherwise.
+	archiveMountDir = "/driver-archive"
 )
 
-type ServerInstance struct {
+// dockerInstance is the original ServerInstance backend: it runs bblfshd as
+// a privileged container on a local Docker daemon, mounting the host's
+// Docker socket into it so the driver image can be pulled from there.
+type dockerInstance struct {
 	cli     *docker.Client
 	user    *grpc.ClientConn
 	bblfshd *docker.Container
 }
 
-func (d *ServerInstance) installFromDocker(ctx context.Context, lang, id string) error {
-	cmd := []string{"bblfshctl", "driver", "install", lang, dockerSchema + id}
+func (d *dockerInstance) installFromDocker(ctx context.Context, lang, id, ref string) error {
+	cmd := []string{"bblfshctl", "driver", "install", lang, ref}
 	printCommand("docker", append([]string{"exec", id}, cmd...)...)
 	e, err := d.cli.CreateExec(docker.CreateExecOptions{
 		Context:      ctx,
@@ -48,7 +57,7 @@ func (d *ServerInstance) installFromDocker(ctx context.Context, lang, id string)
 	}
 	return nil
 }
-func (d *ServerInstance) ClientV1(ctx context.Context) (protocol.ProtocolServiceClient, error) {
+func (d *dockerInstance) ClientV1(ctx context.Context) (protocol.ProtocolServiceClient, error) {
 	if d.user == nil {
 		addr := d.bblfshd.NetworkSettings.IPAddress
 		conn, err := grpc.DialContext(ctx, addr+":"+cliPort, grpc.WithInsecure(), grpc.WithBlock())
@@ -59,10 +68,48 @@ func (d *ServerInstance) ClientV1(ctx context.Context) (protocol.ProtocolService
 	}
 	return protocol.NewProtocolServiceClient(d.user), nil
 }
-func (s *ServerInstance) DumpLogs(w io.Writer) error {
+func (s *dockerInstance) DumpLogs(w io.Writer) error {
 	return getLogs(s.cli, s.bblfshd.ID, w)
 }
-func (d *ServerInstance) Close() error {
+
+// StreamLogs follows bblfshd's stdout/stderr as they are written, while
+// DumpLogs returns once the logs written so far are copied, so it can show
+// output while a later call on the instance hangs. The driver install that
+// runWithDriverDocker runs before returning the instance is not covered.
+func (s *dockerInstance) StreamLogs(ctx context.Context, w io.Writer, opts LogOptions) error {
+	pr, pw := io.Pipe()
+	go func() {
+		<-ctx.Done()
+		pw.CloseWithError(ctx.Err())
+	}()
+
+	attachErr := make(chan error, 1)
+	go func() {
+		attachErr <- s.cli.AttachToContainer(docker.AttachToContainerOptions{
+			Context:      ctx,
+			Container:    s.bblfshd.ID,
+			OutputStream: pw, ErrorStream: pw,
+			Stream: true, Stdout: true, Stderr: true,
+		})
+	}()
+
+	if err := filterLines(ctx, pr, w, opts); err != nil {
+		return err
+	}
+	return <-attachErr
+}
+
+// Tail writes the last n lines of bblfshd's logs to w.
+func (s *dockerInstance) Tail(n int, w io.Writer) error {
+	return s.cli.Logs(docker.LogsOptions{
+		Container:    s.bblfshd.ID,
+		OutputStream: w, ErrorStream: w,
+		Stdout: true, Stderr: true,
+		Tail: strconv.Itoa(n),
+	})
+}
+
+func (d *dockerInstance) Close() error {
 	if d.user != nil {
 		_ = d.user.Close()
 	}
@@ -71,8 +118,9 @@ func (d *ServerInstance) Close() error {
 	})
 }
 
-// RunWithDriver starts a bblfshd server and installs a specified driver to it.
-func RunWithDriver(lang, id string) (*ServerInstance, error) {
+// runWithDriverDocker starts a bblfshd server on the local Docker daemon and
+// installs a specified driver to it.
+func runWithDriverDocker(lang, id string) (*dockerInstance, error) {
 	cli, err := docker.Dial()
 	if err != nil {
 		return nil, err
@@ -83,6 +131,23 @@ func RunWithDriver(lang, id string) (*ServerInstance, error) {
 		sock = docker.Socket + ":" + docker.Socket
 	)
 
+	ref, err := resolveDriverRef(id)
+	if err != nil {
+		return nil, err
+	}
+	binds := []string{sock}
+	if scheme, path, ok := archiveRefPath(ref); ok {
+		// bblfshctl runs inside the bblfshd container, so a host path in ref
+		// is unreachable unless we bind-mount it in too.
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		mounted := archiveMountDir + "/" + filepath.Base(abs)
+		binds = append(binds, abs+":"+mounted+":ro")
+		ref = scheme + mounted
+	}
+
 	printCommand("docker", "run", "--rm", "--privileged", "-v", sock, bblfshd)
 	c, err := docker.Run(cli, docker.CreateContainerOptions{
 		Config: &docker.Config{
@@ -91,16 +156,16 @@ func RunWithDriver(lang, id string) (*ServerInstance, error) {
 		HostConfig: &docker.HostConfig{
 			AutoRemove: true,
 			Privileged: true,
-			Binds:      []string{sock},
+			Binds:      binds,
 		},
 	})
 	if err != nil {
 		return nil, err
 	}
-	s := &ServerInstance{cli: cli, bblfshd: c}
+	s := &dockerInstance{cli: cli, bblfshd: c}
 	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*3)
 	defer cancel()
-	if err := s.installFromDocker(ctx, lang, id); err != nil {
+	if err := s.installFromDocker(ctx, lang, id, ref); err != nil {
 		s.Close()
 		return nil, err
 	}
@@ -112,4 +177,4 @@ func getLogs(cli *docker.Client, id string, w io.Writer) error {
 		Container: id, OutputStream: w, ErrorStream: w,
 		Logs: true, Stdout: true, Stderr: true,
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,228 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"gopkg.in/bblfsh/sdk.v2/protocol"
+)
+
+const (
+	// kubernetesNamespace is where the ephemeral bblfshd Pod is created.
+	kubernetesNamespace = "default"
+	bblfshdContainer    = "bblfshd"
+)
+
+// kubernetesInstance runs bblfshd as an ephemeral Pod in a Kubernetes
+// cluster, using the current kubeconfig context (or in-cluster config when
+// run from within a Pod).
+type kubernetesInstance struct {
+	cli  *kubernetes.Clientset
+	cfg  *rest.Config
+	pod  *corev1.Pod
+	user *grpc.ClientConn
+}
+
+// runWithDriverKubernetes creates a bblfshd Pod and installs the specified
+// driver to it.
+func runWithDriverKubernetes(lang, id string) (*kubernetesInstance, error) {
+	cfg, err := kubeConfig()
+	if err != nil {
+		return nil, fmt.Errorf("build: loading kubeconfig: %v", err)
+	}
+	cli, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("build: creating clientset: %v", err)
+	}
+
+	name := "bblfshd-" + strings.ToLower(strings.Replace(id, ":", "-", -1))
+	printCommand("kubectl", "run", name, "--image=bblfsh/bblfshd", "--privileged")
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: kubernetesNamespace},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{{
+				Name:  bblfshdContainer,
+				Image: "bblfsh/bblfshd",
+				SecurityContext: &corev1.SecurityContext{
+					Privileged: boolPtr(true),
+				},
+			}},
+		},
+	}
+	pod, err = cli.CoreV1().Pods(kubernetesNamespace).Create(context.Background(), pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("build: creating pod: %v", err)
+	}
+
+	s := &kubernetesInstance{cli: cli, cfg: cfg, pod: pod}
+	if err := s.waitRunning(time.Minute); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*3)
+	defer cancel()
+	if err := s.install(ctx, lang, id); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *kubernetesInstance) waitRunning(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		pod, err := s.cli.CoreV1().Pods(kubernetesNamespace).Get(context.Background(), s.pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		s.pod = pod
+		if pod.Status.Phase == corev1.PodRunning && pod.Status.PodIP != "" {
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+	return fmt.Errorf("build: timed out waiting for pod %s to be running", s.pod.Name)
+}
+
+// install execs `bblfshctl driver install` inside the bblfshd container,
+// the Pod equivalent of docker exec used by the Docker backend. It resolves
+// id through resolveDriverRefRegistry rather than resolveDriverRef, since
+// this backend has no Docker daemon for a bare id to fall back to. Local
+// docker-archive:/oci-archive: references aren't supported here yet: unlike
+// the Docker backend, which bind-mounts the resolved archive into the
+// container (see dev.go), a Pod's volumes can't be changed after creation,
+// so such a reference would fail inside the container with a
+// file-not-found error; reject it up front instead.
+func (s *kubernetesInstance) install(ctx context.Context, lang, id string) error {
+	ref, err := resolveDriverRefRegistry(id)
+	if err != nil {
+		return err
+	}
+	if _, _, ok := archiveRefPath(ref); ok {
+		return fmt.Errorf("build: %s: local driver archives are not supported on the kubernetes runtime", id)
+	}
+
+	req := s.cli.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(s.pod.Name).
+		Namespace(kubernetesNamespace).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: bblfshdContainer,
+			Command:   []string{"bblfshctl", "driver", "install", lang, ref},
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	exec, err := remotecommand.NewSPDYExecutor(s.cfg, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+	buf := bytes.NewBuffer(nil)
+	err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: buf, Stderr: buf})
+	if err != nil {
+		return err
+	} else if str := buf.String(); strings.Contains(strings.ToLower(str), "error") {
+		return errors.New(strings.TrimSpace(str))
+	}
+	return nil
+}
+
+func (s *kubernetesInstance) ClientV1(ctx context.Context) (protocol.ProtocolServiceClient, error) {
+	if s.user == nil {
+		addr := s.pod.Status.PodIP
+		conn, err := grpc.DialContext(ctx, addr+":"+cliPort, grpc.WithInsecure(), grpc.WithBlock())
+		if err != nil {
+			return nil, err
+		}
+		s.user = conn
+	}
+	return protocol.NewProtocolServiceClient(s.user), nil
+}
+
+func (s *kubernetesInstance) DumpLogs(w io.Writer) error {
+	req := s.cli.CoreV1().Pods(kubernetesNamespace).GetLogs(s.pod.Name, &corev1.PodLogOptions{
+		Container: bblfshdContainer,
+	})
+	rc, err := req.Stream(context.Background())
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = io.Copy(w, rc)
+	return err
+}
+
+// StreamLogs follows bblfshd's stdout/stderr through the kubelet's log
+// endpoint (PodLogOptions.Follow), the Pod equivalent of the Docker
+// backend's Stream:true attach.
+func (s *kubernetesInstance) StreamLogs(ctx context.Context, w io.Writer, opts LogOptions) error {
+	plOpts := &corev1.PodLogOptions{Container: bblfshdContainer, Follow: true}
+	if !opts.Since.IsZero() {
+		since := metav1.NewTime(opts.Since)
+		plOpts.SinceTime = &since
+	}
+	req := s.cli.CoreV1().Pods(kubernetesNamespace).GetLogs(s.pod.Name, plOpts)
+	rc, err := req.Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return filterLines(ctx, rc, w, opts)
+}
+
+// Tail writes the last n lines of bblfshd's logs to w, using the kubelet's
+// own log buffer rather than keeping one in this process.
+func (s *kubernetesInstance) Tail(n int, w io.Writer) error {
+	lines := int64(n)
+	req := s.cli.CoreV1().Pods(kubernetesNamespace).GetLogs(s.pod.Name, &corev1.PodLogOptions{
+		Container: bblfshdContainer,
+		TailLines: &lines,
+	})
+	rc, err := req.Stream(context.Background())
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = io.Copy(w, rc)
+	return err
+}
+
+func (s *kubernetesInstance) Close() error {
+	if s.user != nil {
+		_ = s.user.Close()
+	}
+	grace := int64(0)
+	return s.cli.CoreV1().Pods(kubernetesNamespace).Delete(context.Background(), s.pod.Name, metav1.DeleteOptions{
+		GracePeriodSeconds: &grace,
+	})
+}
+
+// kubeConfig loads the cluster config the same way kubectl does: in-cluster
+// when run from within a Pod, otherwise from the default kubeconfig.
+func kubeConfig() (*rest.Config, error) {
+	if cfg, err := rest.InClusterConfig(); err == nil {
+		return cfg, nil
+	}
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	overrides := &clientcmd.ConfigOverrides{}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+}
+
+func boolPtr(b bool) *bool { return &b }
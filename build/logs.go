@@ -0,0 +1,192 @@
+package build
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogLevel mirrors the level names bblfshd's logrus output uses, ordered
+// from least to most severe.
+type LogLevel string
+
+const (
+	LogTrace LogLevel = "trace"
+	LogDebug LogLevel = "debug"
+	LogInfo  LogLevel = "info"
+	LogWarn  LogLevel = "warning"
+	LogError LogLevel = "error"
+	LogFatal LogLevel = "fatal"
+)
+
+var logLevelRank = map[LogLevel]int{
+	LogTrace: 0, LogDebug: 1, LogInfo: 2, LogWarn: 3, LogError: 4, LogFatal: 5,
+}
+
+// LogOptions filters the output of ServerInstance.StreamLogs.
+type LogOptions struct {
+	// MinLevel drops log entries below this severity. The zero value
+	// disables the filter.
+	MinLevel LogLevel
+	// Driver, when set, only keeps lines whose "driver" field contains it.
+	Driver string
+	// Since, when non-zero, drops entries timestamped earlier than it.
+	Since time.Time
+}
+
+// logrusEntry is the subset of bblfshd's logrus JSON output this package
+// understands; unknown fields are ignored and non-JSON lines are kept as-is.
+type logrusEntry struct {
+	Time   time.Time `json:"time"`
+	Level  string    `json:"level"`
+	Msg    string    `json:"msg"`
+	Driver string    `json:"driver"`
+}
+
+// match reports whether line passes the filter in o. Lines that aren't
+// valid logrus JSON are always kept, since not every bblfshd component logs
+// in JSON.
+func (o LogOptions) match(line string) bool {
+	var e logrusEntry
+	if err := json.Unmarshal([]byte(line), &e); err != nil {
+		return true
+	}
+	if o.MinLevel != "" {
+		if rank, ok := logLevelRank[LogLevel(e.Level)]; ok && rank < logLevelRank[o.MinLevel] {
+			return false
+		}
+	}
+	if o.Driver != "" && !strings.Contains(e.Driver, o.Driver) {
+		return false
+	}
+	if !o.Since.IsZero() && e.Time.Before(o.Since) {
+		return false
+	}
+	return true
+}
+
+// filterLines copies newline-delimited log lines from r into w, dropping
+// ones opts.match rejects, until r is exhausted or ctx is canceled.
+func filterLines(ctx context.Context, r io.Reader, w io.Writer, opts LogOptions) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if line := sc.Text(); opts.match(line) {
+			if _, err := io.WriteString(w, line+"\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return sc.Err()
+}
+
+// logHub fans bblfshd's stdout/stderr out to any number of concurrent
+// StreamLogs callers and keeps the last maxKeep lines for Tail. It is used
+// by the containerd runtime, which, unlike the Docker and Kubernetes
+// backends, has no daemon-managed log buffer to read back from.
+type logHub struct {
+	maxKeep int
+
+	mu    sync.Mutex
+	lines []string
+	subs  map[chan string]struct{}
+	buf   []byte
+}
+
+func newLogHub(maxKeep int) *logHub {
+	return &logHub{maxKeep: maxKeep, subs: make(map[chan string]struct{})}
+}
+
+// Write implements io.Writer, splitting p on newlines and buffering a
+// trailing partial line until it is completed by a later Write.
+func (h *logHub) Write(p []byte) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.buf = append(h.buf, p...)
+	for {
+		i := bytes.IndexByte(h.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(h.buf[:i])
+		h.buf = h.buf[i+1:]
+
+		h.lines = append(h.lines, line)
+		if len(h.lines) > h.maxKeep {
+			h.lines = h.lines[len(h.lines)-h.maxKeep:]
+		}
+		for ch := range h.subs {
+			select {
+			case ch <- line:
+			default: // drop if a slow subscriber isn't keeping up
+			}
+		}
+	}
+	return len(p), nil
+}
+
+// tail returns the last n buffered lines, fewer if there aren't that many.
+func (h *logHub) tail(n int) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if n <= 0 || n > len(h.lines) {
+		n = len(h.lines)
+	}
+	out := make([]string, n)
+	copy(out, h.lines[len(h.lines)-n:])
+	return out
+}
+
+// subscribe registers ch to receive every subsequent line written to h,
+// until cancel is called.
+func (h *logHub) subscribe(ch chan string) (cancel func()) {
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	return func() {
+		h.mu.Lock()
+		delete(h.subs, ch)
+		h.mu.Unlock()
+	}
+}
+
+// stream writes h's buffered tail followed by live lines into w, filtered
+// by opts, until ctx is canceled.
+func (h *logHub) stream(ctx context.Context, w io.Writer, opts LogOptions) error {
+	// Subscribe before reading the tail snapshot: if a line arrives in
+	// between, we'd rather write it twice than drop it.
+	ch := make(chan string, 256)
+	defer h.subscribe(ch)()
+
+	for _, line := range h.tail(h.maxKeep) {
+		if opts.match(line) {
+			if _, err := io.WriteString(w, line+"\n"); err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case line := <-ch:
+			if opts.match(line) {
+				if _, err := io.WriteString(w, line+"\n"); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogOptionsMatchNonJSON(t *testing.T) {
+	o := LogOptions{MinLevel: LogError}
+	if !o.match("not a logrus line") {
+		t.Fatal("non-JSON lines should always be kept")
+	}
+}
+
+func TestLogOptionsMatchMinLevel(t *testing.T) {
+	o := LogOptions{MinLevel: LogWarn}
+	if o.match(`{"level":"info","msg":"hi"}`) {
+		t.Fatal("info should be dropped below a warning MinLevel")
+	}
+	if !o.match(`{"level":"error","msg":"hi"}`) {
+		t.Fatal("error should pass a warning MinLevel")
+	}
+}
+
+func TestLogOptionsMatchDriver(t *testing.T) {
+	o := LogOptions{Driver: "python"}
+	if !o.match(`{"driver":"python-driver","msg":"hi"}`) {
+		t.Fatal("expected a matching driver substring to pass")
+	}
+	if o.match(`{"driver":"go-driver","msg":"hi"}`) {
+		t.Fatal("expected a non-matching driver to be dropped")
+	}
+}
+
+func TestLogOptionsMatchSince(t *testing.T) {
+	since := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	o := LogOptions{Since: since}
+	before := `{"time":"2019-12-31T23:00:00Z","msg":"old"}`
+	after := `{"time":"2020-01-02T00:00:00Z","msg":"new"}`
+	if o.match(before) {
+		t.Fatal("expected an entry before Since to be dropped")
+	}
+	if !o.match(after) {
+		t.Fatal("expected an entry after Since to pass")
+	}
+}
+
+func TestFilterLines(t *testing.T) {
+	in := strings.NewReader("plain line\n" +
+		`{"level":"debug","msg":"skip"}` + "\n" +
+		`{"level":"error","msg":"keep"}` + "\n")
+	var out bytes.Buffer
+	if err := filterLines(context.Background(), in, &out, LogOptions{MinLevel: LogWarn}); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	if !strings.Contains(got, "plain line") {
+		t.Errorf("expected plain non-JSON line to pass, got %q", got)
+	}
+	if strings.Contains(got, "skip") {
+		t.Errorf("expected debug line to be filtered out, got %q", got)
+	}
+	if !strings.Contains(got, "keep") {
+		t.Errorf("expected error line to pass, got %q", got)
+	}
+}
+
+func TestFilterLinesCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	in := strings.NewReader("one\ntwo\nthree\n")
+	err := filterLines(ctx, in, &bytes.Buffer{}, LogOptions{})
+	if err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestLogHubTail(t *testing.T) {
+	h := newLogHub(2)
+	_, _ = h.Write([]byte("a\nb\nc\n"))
+	got := h.tail(10)
+	want := []string{"b", "c"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("tail = %v, want %v", got, want)
+	}
+}
+
+func TestLogHubTailPartialLineBuffered(t *testing.T) {
+	h := newLogHub(10)
+	_, _ = h.Write([]byte("complete\nincomplete"))
+	got := h.tail(10)
+	if len(got) != 1 || got[0] != "complete" {
+		t.Fatalf("tail = %v, want [complete]", got)
+	}
+	_, _ = h.Write([]byte(" now\n"))
+	got = h.tail(10)
+	if len(got) != 2 || got[1] != "incomplete now" {
+		t.Fatalf("tail = %v, want the completed second line", got)
+	}
+}
+
+func TestLogHubSubscribe(t *testing.T) {
+	h := newLogHub(10)
+	ch := make(chan string, 1)
+	cancel := h.subscribe(ch)
+	defer cancel()
+
+	_, _ = h.Write([]byte("hello\n"))
+	select {
+	case line := <-ch:
+		if line != "hello" {
+			t.Fatalf("got %q, want %q", line, "hello")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for subscribed line")
+	}
+}
+
+func TestLogHubStream(t *testing.T) {
+	h := newLogHub(10)
+	_, _ = h.Write([]byte("buffered\n"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var out bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		done <- h.stream(ctx, &out, LogOptions{})
+	}()
+
+	// give stream time to subscribe before writing a live line
+	time.Sleep(50 * time.Millisecond)
+	_, _ = h.Write([]byte("live\n"))
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	got := out.String()
+	if !strings.Contains(got, "buffered") {
+		t.Errorf("expected buffered tail line in output, got %q", got)
+	}
+	if !strings.Contains(got, "live") {
+		t.Errorf("expected live line in output, got %q", got)
+	}
+}
@@ -0,0 +1,129 @@
+package build
+
+import (
+	"archive/tar"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+)
+
+// Transport schemes accepted by `bblfshctl driver install`, matching the
+// ones understood by skopeo/containers-image.
+const (
+	transportDockerDaemon  = "docker-daemon:"
+	transportDockerArchive = "docker-archive:"
+	transportOCIArchive    = "oci-archive:"
+	transportDocker        = "docker://"
+)
+
+// resolveDriverRef turns a user-supplied driver image reference into the
+// fully-qualified transport URI that bblfshctl understands. It accepts:
+//
+//   - an already-qualified reference: docker-daemon:, docker-archive: and
+//     oci-archive: ones are returned unchanged, docker:// ones are
+//     normalized as described on resolveRegistryRef;
+//   - a path to a local tarball produced by `docker save` or
+//     `buildah push oci-archive:`, detected by inspecting the tar layout;
+//   - a bare image ID already loaded into the local Docker daemon, which
+//     keeps behaving exactly as before and is prefixed with docker-daemon:.
+//
+// Use an explicit "docker://name:tag" reference to pull from a registry
+// instead of resolving against the daemon. This is the resolver used by the
+// Docker runtime backend; the containerd and Kubernetes backends, which
+// have no Docker daemon to resolve a bare id against, use
+// resolveDriverRefRegistry instead.
+func resolveDriverRef(id string) (string, error) {
+	return resolveDriverRefDefault(id, dockerSchema)
+}
+
+// resolveDriverRefRegistry is like resolveDriverRef but, lacking a Docker
+// daemon to fall back to, resolves a bare id as a registry pull instead of
+// a docker-daemon: reference. It is what the containerd and Kubernetes
+// runtime backends use.
+func resolveDriverRefRegistry(id string) (string, error) {
+	return resolveDriverRefDefault(id, transportDocker)
+}
+
+// resolveDriverRefDefault implements resolveDriverRef and
+// resolveDriverRefRegistry, varying only in how a bare id (no known scheme,
+// no local archive) is resolved.
+func resolveDriverRefDefault(id, fallbackScheme string) (string, error) {
+	if hasKnownScheme(id) {
+		if strings.HasPrefix(id, transportDocker) {
+			return resolveRegistryRef(strings.TrimPrefix(id, transportDocker))
+		}
+		return id, nil
+	}
+	if fi, err := os.Stat(id); err == nil && !fi.IsDir() {
+		transport, err := archiveTransport(id)
+		if err != nil {
+			return "", fmt.Errorf("build: %s: %v", id, err)
+		}
+		return transport + id, nil
+	}
+	if fallbackScheme == transportDocker {
+		return resolveRegistryRef(id)
+	}
+	return fallbackScheme + id, nil
+}
+
+// archiveRefPath reports whether ref names a local archive (docker-archive:
+// or oci-archive:), returning the scheme and the path that follows it.
+func archiveRefPath(ref string) (scheme, path string, ok bool) {
+	for _, s := range []string{transportDockerArchive, transportOCIArchive} {
+		if strings.HasPrefix(ref, s) {
+			return s, strings.TrimPrefix(ref, s), true
+		}
+	}
+	return "", "", false
+}
+
+func hasKnownScheme(id string) bool {
+	for _, scheme := range []string{transportDockerDaemon, transportDockerArchive, transportOCIArchive, transportDocker} {
+		if strings.HasPrefix(id, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveTransport inspects a local tar file and tells apart an OCI image
+// layout (identified by its "oci-layout" marker file) from a legacy
+// `docker save` archive (identified by its "manifest.json").
+func archiveTransport(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		switch hdr.Name {
+		case "oci-layout":
+			return transportOCIArchive, nil
+		case "manifest.json":
+			return transportDockerArchive, nil
+		}
+	}
+	return "", fmt.Errorf("not a recognized OCI or Docker image archive")
+}
+
+// resolveRegistryRef normalizes a bare image reference the same way the
+// Docker daemon does: it defaults the domain to docker.io, the path to
+// library/<name> for official images, and the tag to latest when neither a
+// tag nor a digest was given.
+func resolveRegistryRef(id string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(id)
+	if err != nil {
+		return "", fmt.Errorf("build: invalid driver image reference %q: %v", id, err)
+	}
+	named = reference.TagNameOnly(named)
+	return transportDocker + named.String(), nil
+}
@@ -0,0 +1,114 @@
+package build
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveDriverRefKnownScheme(t *testing.T) {
+	ref, err := resolveDriverRef("docker-daemon:abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref != "docker-daemon:abc123" {
+		t.Fatalf("got %q", ref)
+	}
+}
+
+func TestResolveDriverRefBareID(t *testing.T) {
+	ref, err := resolveDriverRef("abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref != dockerSchema+"abc123" {
+		t.Fatalf("got %q", ref)
+	}
+}
+
+func TestResolveDriverRefDockerScheme(t *testing.T) {
+	ref, err := resolveDriverRef("docker://bblfsh/python-driver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref != "docker://docker.io/bblfsh/python-driver:latest" {
+		t.Fatalf("got %q", ref)
+	}
+}
+
+func TestResolveDriverRefRegistryBareID(t *testing.T) {
+	ref, err := resolveDriverRefRegistry("bblfsh/python-driver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref != "docker://docker.io/bblfsh/python-driver:latest" {
+		t.Fatalf("got %q", ref)
+	}
+}
+
+func TestResolveDriverRefRegistryKnownScheme(t *testing.T) {
+	ref, err := resolveDriverRefRegistry("docker-daemon:abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref != "docker-daemon:abc123" {
+		t.Fatalf("got %q", ref)
+	}
+}
+
+func TestResolveDriverRefArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "driver.tar")
+	writeTar(t, path, map[string]string{"manifest.json": "[]"})
+
+	ref, err := resolveDriverRef(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref != transportDockerArchive+path {
+		t.Fatalf("got %q", ref)
+	}
+}
+
+func TestArchiveTransportOCI(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "driver.tar")
+	writeTar(t, path, map[string]string{"oci-layout": "{}"})
+
+	transport, err := archiveTransport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport != transportOCIArchive {
+		t.Fatalf("got %q", transport)
+	}
+}
+
+func TestArchiveTransportUnrecognized(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "driver.tar")
+	writeTar(t, path, map[string]string{"readme.txt": "nothing useful here"})
+
+	if _, err := archiveTransport(path); err == nil {
+		t.Fatal("expected an error for an archive without a recognized layout")
+	}
+}
+
+func writeTar(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tw := tar.NewWriter(f)
+	defer tw.Close()
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Size: int64(len(content)), Mode: 0600}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
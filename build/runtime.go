@@ -0,0 +1,76 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/bblfsh/sdk.v2/protocol"
+)
+
+// Runtime selects the backend used to run bblfshd while testing a driver.
+type Runtime string
+
+const (
+	// RuntimeDocker runs bblfshd as a container on a local Docker daemon.
+	// It is the default and the only runtime supported historically.
+	RuntimeDocker Runtime = "docker"
+	// RuntimeContainerd runs bblfshd directly against a containerd/CRI
+	// socket, without requiring a Docker daemon. Useful in rootless CI
+	// environments.
+	RuntimeContainerd Runtime = "containerd"
+	// RuntimeKubernetes runs bblfshd as an ephemeral Pod in a Kubernetes
+	// cluster, using the current kubeconfig context.
+	RuntimeKubernetes Runtime = "kubernetes"
+)
+
+// runtimeEnv overrides the runtime used by RunWithDriver when set.
+const runtimeEnv = "BBLFSH_SDK_RUNTIME"
+
+// ServerInstance is a running bblfshd instance with a driver installed to it.
+// It is implemented by each supported Runtime backend.
+type ServerInstance interface {
+	// ClientV1 returns a client connected to the instance, dialing lazily.
+	ClientV1(ctx context.Context) (protocol.ProtocolServiceClient, error)
+	// DumpLogs writes the historical stdout/stderr of bblfshd to w.
+	DumpLogs(w io.Writer) error
+	// StreamLogs follows bblfshd's stdout/stderr in real time, applying
+	// opts as a filter, until ctx is canceled. Unlike DumpLogs, which
+	// returns once the logs written so far are copied, it keeps following
+	// new output, so it can run in another goroutine while a call on the
+	// instance hangs. It needs the instance that RunWithDriver or
+	// RunWithDriverRuntime returns, so it does not cover the driver install
+	// those functions run before returning.
+	StreamLogs(ctx context.Context, w io.Writer, opts LogOptions) error
+	// Tail writes the last n buffered log lines to w.
+	Tail(n int, w io.Writer) error
+	// Close stops the instance and releases any resources it holds.
+	Close() error
+}
+
+// RunWithDriver starts a bblfshd server and installs a specified driver to
+// it, using the runtime backend selected by the BBLFSH_SDK_RUNTIME
+// environment variable (defaults to Docker).
+func RunWithDriver(lang, id string) (ServerInstance, error) {
+	rt := Runtime(os.Getenv(runtimeEnv))
+	if rt == "" {
+		rt = RuntimeDocker
+	}
+	return RunWithDriverRuntime(rt, lang, id)
+}
+
+// RunWithDriverRuntime is like RunWithDriver but lets the caller pick the
+// runtime explicitly, e.g. from a `bblfsh-sdk test --runtime` flag.
+func RunWithDriverRuntime(rt Runtime, lang, id string) (ServerInstance, error) {
+	switch rt {
+	case RuntimeDocker:
+		return runWithDriverDocker(lang, id)
+	case RuntimeContainerd:
+		return runWithDriverContainerd(lang, id)
+	case RuntimeKubernetes:
+		return runWithDriverKubernetes(lang, id)
+	default:
+		return nil, fmt.Errorf("build: unknown runtime %q", rt)
+	}
+}
@@ -0,0 +1,125 @@
+package fixtures
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffMode selects how a Suite renders an expected/actual fixture mismatch.
+type DiffMode int
+
+const (
+	// DiffOff renders no diff beyond the existing "run diff -d" message.
+	DiffOff DiffMode = iota
+	// DiffUnified renders a colorized unified diff.
+	DiffUnified
+	// DiffSideBySide renders expected and actual columns side by side.
+	DiffSideBySide
+)
+
+const (
+	ansiRed   = "\033[31m"
+	ansiGreen = "\033[32m"
+	ansiReset = "\033[0m"
+)
+
+// diffOp is one line of a line-level edit script, as produced by diffLines.
+type diffOp struct {
+	kind byte // ' ' (equal), '-' (only in exp) or '+' (only in got)
+	text string
+}
+
+// diffLines computes a line-level diff between exp and got in-process (no
+// external `diff` binary), via the classic LCS backtrace also used by
+// Myers' algorithm for the common case of line-granular text.
+func diffLines(exp, got string) []diffOp {
+	a := strings.Split(exp, "\n")
+	b := strings.Split(got, "\n")
+
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// renderDiff renders ops in the given mode. DiffOff renders an empty string.
+func renderDiff(mode DiffMode, ops []diffOp) string {
+	switch mode {
+	case DiffUnified:
+		return renderUnified(ops)
+	case DiffSideBySide:
+		return renderSideBySide(ops)
+	default:
+		return ""
+	}
+}
+
+func renderUnified(ops []diffOp) string {
+	var b strings.Builder
+	for _, op := range ops {
+		switch op.kind {
+		case '-':
+			fmt.Fprintf(&b, "%s-%s%s\n", ansiRed, op.text, ansiReset)
+		case '+':
+			fmt.Fprintf(&b, "%s+%s%s\n", ansiGreen, op.text, ansiReset)
+		default:
+			fmt.Fprintf(&b, " %s\n", op.text)
+		}
+	}
+	return b.String()
+}
+
+// sideBySideWidth is the column width used to align the expected/actual
+// panes; longer lines are left untruncated.
+const sideBySideWidth = 60
+
+func renderSideBySide(ops []diffOp) string {
+	var b strings.Builder
+	for _, op := range ops {
+		switch op.kind {
+		case ' ':
+			fmt.Fprintf(&b, "%-*s | %s\n", sideBySideWidth, op.text, op.text)
+		case '-':
+			fmt.Fprintf(&b, "%s%-*s%s | %s\n", ansiRed, sideBySideWidth, op.text, ansiReset, "")
+		case '+':
+			fmt.Fprintf(&b, "%-*s | %s%s%s\n", sideBySideWidth, "", ansiGreen, op.text, ansiReset)
+		}
+	}
+	return b.String()
+}
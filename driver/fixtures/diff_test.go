@@ -0,0 +1,77 @@
+package fixtures
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffLinesEqual(t *testing.T) {
+	ops := diffLines("a\nb", "a\nb")
+	for _, op := range ops {
+		if op.kind != ' ' {
+			t.Fatalf("unexpected op %q %q for identical input", op.kind, op.text)
+		}
+	}
+}
+
+func TestDiffLinesChange(t *testing.T) {
+	ops := diffLines("a\nb\nc", "a\nx\nc")
+
+	var removed, added bool
+	for _, op := range ops {
+		if op.kind == '-' && op.text == "b" {
+			removed = true
+		}
+		if op.kind == '+' && op.text == "x" {
+			added = true
+		}
+	}
+	if !removed || !added {
+		t.Fatalf("expected a removed %q and an added %q, got %+v", "b", "x", ops)
+	}
+}
+
+func TestDiffLinesAppend(t *testing.T) {
+	ops := diffLines("a\nb", "a\nb\nc")
+	last := ops[len(ops)-1]
+	if last.kind != '+' || last.text != "c" {
+		t.Fatalf("expected a trailing +c, got %+v", last)
+	}
+}
+
+func TestRenderDiffModes(t *testing.T) {
+	ops := diffLines("a\nb", "a\nx")
+	if got := renderDiff(DiffOff, ops); got != "" {
+		t.Fatalf("DiffOff should render nothing, got %q", got)
+	}
+	if got := renderDiff(DiffUnified, ops); got != renderUnified(ops) {
+		t.Fatalf("DiffUnified should delegate to renderUnified")
+	}
+	if got := renderDiff(DiffSideBySide, ops); got != renderSideBySide(ops) {
+		t.Fatalf("DiffSideBySide should delegate to renderSideBySide")
+	}
+}
+
+func TestRenderUnified(t *testing.T) {
+	ops := []diffOp{{' ', "same"}, {'-', "old"}, {'+', "new"}}
+	out := renderUnified(ops)
+	if !strings.Contains(out, "-old") {
+		t.Errorf("missing removed line in %q", out)
+	}
+	if !strings.Contains(out, "+new") {
+		t.Errorf("missing added line in %q", out)
+	}
+	if !strings.Contains(out, " same") {
+		t.Errorf("missing unchanged line in %q", out)
+	}
+}
+
+func TestRenderSideBySide(t *testing.T) {
+	ops := []diffOp{{' ', "same"}, {'-', "old"}, {'+', "new"}}
+	out := renderSideBySide(ops)
+	for _, want := range []string{"same", "old", "new"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in %q", want, out)
+		}
+	}
+}
@@ -2,9 +2,12 @@ package fixtures
 
 import (
 	"context"
+	"flag"
+	"hash/fnv"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"testing"
@@ -22,6 +25,11 @@ import (
 	"gopkg.in/bblfsh/sdk.v2/uast/yaml"
 )
 
+var (
+	flagParallel = flag.Int("fixtures.parallel", 1, "number of fixture subtests to run concurrently")
+	flagShard    = flag.String("fixtures.shard", "", "run only one shard of the fixtures, as i/n (e.g. 2/4)")
+)
+
 const Dir = "fixtures"
 
 const (
@@ -44,6 +52,15 @@ type DockerConfig struct {
 	Image string
 }
 
+// Shard restricts a Suite run to one slice of the fixture corpus, out of
+// Total equal shards, so a large corpus can be split across CI matrix jobs.
+// Fixtures are assigned to shards by a stable hash of their filename, so a
+// given fixture always lands in the same shard regardless of run order.
+type Shard struct {
+	Index int // 0-based
+	Total int
+}
+
 type Suite struct {
 	Lang string
 	Ext  string // with dot
@@ -68,6 +85,87 @@ type Suite struct {
 	// VerifyTokens checks that token and positional info matches.
 	// Executed after the preprocessing stage (in annotated mode).
 	VerifyTokens []positioner.VerifyToken
+
+	// Parallel is the number of fixture subtests run concurrently with
+	// t.Parallel(). Zero falls back to the -fixtures.parallel flag, which
+	// defaults to 1 (sequential, the historical behavior).
+	Parallel int
+
+	// Shard restricts this run to one shard of the fixture corpus. The zero
+	// value falls back to the -fixtures.shard flag, and runs everything if
+	// that is unset too.
+	Shard Shard
+
+	// Reporter, when set, receives a FixtureReport for every fixture/mode
+	// combination run, except fixtures expected to fail with a syntax error
+	// and, in the uast and semantic modes, fixtures that fail to parse or
+	// transform. Resolved by RunTests from Report (or the
+	// BBLFSH_SDK_FIXTURES_REPORT env var) when left nil.
+	Reporter Reporter
+
+	// Report is the path to write a JSON test report to, for CI dashboards.
+	// See Reporter and jsonReporter.
+	Report string
+
+	// DiffMode selects how a mismatch between a fixture's golden file and
+	// the driver's current output is rendered to t.Log. Defaults to DiffOff,
+	// which keeps the historical "run diff -d" message only.
+	DiffMode DiffMode
+
+	// UpdateInteractive, when set, is consulted on every mismatch instead of
+	// relying solely on the UpdateNative/UpdateUAST booleans, letting
+	// `bblfsh-sdk test -update-interactive` update golden files one fixture
+	// at a time.
+	UpdateInteractive UpdatePrompter
+}
+
+// parallelism returns the effective s.Parallel, falling back to the
+// -fixtures.parallel flag.
+func (s *Suite) parallelism() int {
+	if s.Parallel > 0 {
+		return s.Parallel
+	}
+	if flagParallel != nil && *flagParallel > 0 {
+		return *flagParallel
+	}
+	return 1
+}
+
+// shard returns the effective s.Shard, falling back to the -fixtures.shard
+// flag.
+func (s *Suite) shard() Shard {
+	if s.Shard.Total > 0 {
+		return s.Shard
+	}
+	if idx, total, ok := parseShardFlag(*flagShard); ok {
+		return Shard{Index: idx, Total: total}
+	}
+	return Shard{}
+}
+
+// parseShardFlag parses the "i/n" syntax accepted by -fixtures.shard.
+func parseShardFlag(s string) (index, total int, ok bool) {
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	i, err1 := strconv.Atoi(parts[0])
+	n, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || n <= 0 || i < 0 || i >= n {
+		return 0, 0, false
+	}
+	return i, n, true
+}
+
+// inShard reports whether fname belongs to sh, based on a stable hash of
+// the filename. A zero-value Shard (Total == 0) always matches.
+func inShard(sh Shard, fname string) bool {
+	if sh.Total <= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(fname))
+	return int(h.Sum32()%uint32(sh.Total)) == sh.Index
 }
 
 func (s *Suite) fixturesPath(name string) string {
@@ -102,6 +200,9 @@ func (s *Suite) RunTests(t *testing.T) {
 		s.runTestsDocker(t)
 		return
 	}
+	if s.Reporter == nil {
+		s.Reporter = s.reporter()
+	}
 	t.Run("native", s.testFixturesNative)
 	t.Run("uast", func(t *testing.T) {
 		s.testFixturesUAST(t, driver.ModeAnnotated, uastExt)
@@ -109,6 +210,11 @@ func (s *Suite) RunTests(t *testing.T) {
 	t.Run("semantic", func(t *testing.T) {
 		s.testFixturesUAST(t, driver.ModeSemantic, highExt, s.Semantic.BlacklistTypes...)
 	})
+	if jr, ok := s.Reporter.(*jsonReporter); ok {
+		if err := jr.Flush(); err != nil {
+			t.Errorf("fixtures: writing report: %v", err)
+		}
+	}
 }
 
 func (s *Suite) RunBenchmarks(b *testing.B) {
@@ -137,6 +243,15 @@ func marshalUAST(o nodes.Node) ([]byte, error) {
 	return uastyml.Marshal(o)
 }
 
+// modeName gives the FixtureReport.Mode value for a driver.Mode, matching
+// the test subtest names used in RunTests ("uast"/"semantic").
+func modeName(mode driver.Mode) string {
+	if mode >= driver.ModeSemantic {
+		return "semantic"
+	}
+	return "uast"
+}
+
 func isTest(name, ext string) (string, bool) {
 	if !strings.HasSuffix(name, ext) {
 		return "", false
@@ -144,62 +259,132 @@ func isTest(name, ext string) (string, bool) {
 	return strings.TrimSuffix(name, ext), true
 }
 
+// driverPool starts s.parallelism() driver instances for subtests to share.
+// With the default parallelism of 1 it behaves like the single driver
+// instance reused sequentially before Parallel/Shard were introduced.
+func (s *Suite) driverPool(t testing.TB) (acquire func() driver.Native, release func(driver.Native), closeAll func()) {
+	n := s.parallelism()
+	pool := make(chan driver.Native, n)
+	for i := 0; i < n; i++ {
+		dr := s.NewDriver()
+		require.NoError(t, dr.Start())
+		pool <- dr
+	}
+	acquire = func() driver.Native { return <-pool }
+	release = func(dr driver.Native) { pool <- dr }
+	closeAll = func() {
+		close(pool)
+		for dr := range pool {
+			dr.Close()
+		}
+	}
+	return acquire, release, closeAll
+}
+
 func (s *Suite) testFixturesNative(t *testing.T) {
 	list, err := ioutil.ReadDir(s.Path)
 	require.NoError(t, err)
 
-	dr := s.NewDriver()
-
-	err = dr.Start()
-	require.NoError(t, err)
-	defer dr.Close()
-
-	var parseErrors uint32
+	parallel := s.parallelism() > 1
+	sh := s.shard()
+	acquire, release, closeAll := s.driverPool(t)
+	// t.Parallel() subtests registered below don't actually run until this
+	// function returns, so a bare defer here would close every pooled driver
+	// out from under them. t.Cleanup runs after they've all finished instead.
+	t.Cleanup(closeAll)
+
+	// parseErrors counts parse failures of the subtests run by this call,
+	// which cover this process's one shard. With parallel subtests the
+	// early abort in the loop below is disabled, since t.Parallel()
+	// subtests only run after the loop returns; each subtest instead skips
+	// once the limit is reached. quit behaves the same way for an
+	// interactive "quit".
+	// updateAll mirrors a DecisionUpdateAll choice for the rest of this run;
+	// it has to be atomic too rather than writing s.UpdateNative directly,
+	// since parallel subtests read and write it concurrently.
+	var parseErrors, quit, updateAll uint32
+	if s.UpdateNative {
+		updateAll = 1
+	}
 
 	suffix := s.Ext
 	for _, ent := range list {
 		fname := ent.Name()
 		name, ok := isTest(fname, suffix)
-		if !ok {
+		if !ok || !inShard(sh, fname) {
 			continue
-		} else if atomic.LoadUint32(&parseErrors) >= maxParseErrors {
+		} else if !parallel && (atomic.LoadUint32(&parseErrors) >= maxParseErrors || atomic.LoadUint32(&quit) != 0) {
 			return
 		}
 
 		t.Run(name, func(t *testing.T) {
-			if atomic.LoadUint32(&parseErrors) >= maxParseErrors {
+			if parallel {
+				t.Parallel()
+			}
+			if atomic.LoadUint32(&parseErrors) >= maxParseErrors || atomic.LoadUint32(&quit) != 0 {
 				t.SkipNow()
 			}
+			dr := acquire()
+			defer release(dr)
+
 			code := s.readFixturesFile(t, fname)
 
+			start := time.Now()
 			ctx, cancel := context.WithTimeout(context.Background(), parseTimeout)
 			resp, err := dr.Parse(ctx, string(code))
 			cancel()
+			dur := time.Since(start)
 			if strings.Contains(fname, syntaxErrTestName) {
 				require.True(t, err != nil && !driver.ErrDriverFailure.Is(err), "unexpected error: %v", err)
 				return
 			}
 			if err != nil {
 				atomic.AddUint32(&parseErrors, 1)
+				s.reportFixture(FixtureReport{ID: s.Lang + "/" + name, Mode: "native", Duration: dur, Error: err.Error()})
 			}
 			require.NoError(t, err)
 
 			js, err := marshalNative(resp)
 			require.NoError(t, err)
 
+			rec := FixtureReport{ID: s.Lang + "/" + name, Mode: "native", Duration: dur, NativeBytes: len(js)}
+
 			exp := s.readFixturesFile(t, fname+nativeExt)
 			got := string(js)
 			if exp == "" {
 				s.writeFixturesFile(t, fname+nativeExt, got)
+				s.reportFixture(rec)
 				t.Skip("no test file found - generating")
 			}
 			if !assert.ObjectsAreEqual(exp, got) {
+				diff := summarizeDiff(exp, got)
+				rec.Mismatch = &diff
+				s.reportFixture(rec)
+
+				ops := diffLines(exp, got)
+				if rendered := renderDiff(s.DiffMode, ops); rendered != "" {
+					t.Log(rendered)
+				}
+
+				update := atomic.LoadUint32(&updateAll) != 0
+				if s.UpdateInteractive != nil {
+					switch s.UpdateInteractive(fname, renderUnified(ops)) {
+					case DecisionUpdate:
+						update = true
+					case DecisionUpdateAll:
+						update = true
+						atomic.StoreUint32(&updateAll, 1)
+					case DecisionQuit:
+						atomic.StoreUint32(&quit, 1)
+					}
+				}
+
 				ext := nativeExt + gotSuffix
-				if s.UpdateNative {
+				if update {
 					ext = nativeExt
 				}
 				s.writeFixturesFile(t, fname+ext, got)
-				if !s.UpdateNative {
+				if !update {
 					require.Fail(t, "unexpected AST returned by the driver",
 						"run diff command to debug:\ndiff -d ./%s ./%s",
 						strings.TrimLeft(s.fixturesPath(fname+ext), "./"),
@@ -209,6 +394,7 @@ func (s *Suite) testFixturesNative(t *testing.T) {
 					t.Skip("force update of native fixtures")
 				}
 			} else {
+				s.reportFixture(rec)
 				s.deleteFixturesFile(fname + nativeExt + gotSuffix)
 			}
 		})
@@ -221,31 +407,45 @@ func (s *Suite) testFixturesUAST(t *testing.T, mode driver.Mode, suf string, bla
 	list, err := ioutil.ReadDir(s.Path)
 	require.NoError(t, err)
 
-	dr := s.NewDriver()
-
-	err = dr.Start()
-	require.NoError(t, err)
-	defer dr.Close()
-
-	var parseErrors uint32
+	parallel := s.parallelism() > 1
+	sh := s.shard()
+	acquire, release, closeAll := s.driverPool(t)
+	// See the matching comment in testFixturesNative: t.Cleanup (not defer)
+	// is required so pooled drivers outlive the t.Parallel() subtests below.
+	t.Cleanup(closeAll)
+
+	// updateAll mirrors a DecisionUpdateAll choice for the rest of this run;
+	// it has to be atomic too rather than writing s.UpdateUAST directly,
+	// since parallel subtests read and write it concurrently.
+	var parseErrors, quit, updateAll uint32
+	if s.UpdateUAST {
+		updateAll = 1
+	}
 
 	suffix := s.Ext
 	for _, ent := range list {
 		fname := ent.Name()
 		name, ok := isTest(fname, suffix)
-		if !ok {
+		if !ok || !inShard(sh, fname) {
 			continue
-		} else if atomic.LoadUint32(&parseErrors) >= maxParseErrors {
+		} else if !parallel && (atomic.LoadUint32(&parseErrors) >= maxParseErrors || atomic.LoadUint32(&quit) != 0) {
 			return
 		}
 
 		t.Run(name, func(t *testing.T) {
-			if atomic.LoadUint32(&parseErrors) >= maxParseErrors {
+			if parallel {
+				t.Parallel()
+			}
+			if atomic.LoadUint32(&parseErrors) >= maxParseErrors || atomic.LoadUint32(&quit) != 0 {
 				t.SkipNow()
 			}
+			dr := acquire()
+			defer release(dr)
+
 			name += suffix
 			code := s.readFixturesFile(t, fname)
 
+			start := time.Now()
 			ctx, cancel := context.WithTimeout(ctx, parseTimeout)
 			ast, err := dr.Parse(ctx, string(code))
 			cancel()
@@ -270,7 +470,15 @@ func (s *Suite) testFixturesUAST(t *testing.T, mode driver.Mode, suf string, bla
 			}
 			ua, err := tr.Do(ctx, mode, code, ast)
 			require.NoError(t, err)
+			dur := time.Since(start)
+
+			nodeCount := 0
+			nodes.WalkPreOrder(ua, func(n nodes.Node) bool {
+				nodeCount++
+				return true
+			})
 
+			var blacklistHits map[string]int
 			if len(blacklist) != 0 {
 				foundBlack := make(map[string]int, len(blacklist))
 				for _, typ := range blacklist {
@@ -289,11 +497,12 @@ func (s *Suite) testFixturesUAST(t *testing.T, mode driver.Mode, suf string, bla
 					}
 					return true
 				})
+				blacklistHits = make(map[string]int)
 				for typ, cnt := range foundBlack {
 					if cnt == 0 {
-						delete(foundBlack, typ)
 						continue
 					}
+					blacklistHits[typ] = cnt
 					t.Errorf("blacklisted nodes of type %q (%d) found in the tree", typ, cnt)
 				}
 			}
@@ -316,9 +525,11 @@ func (s *Suite) testFixturesUAST(t *testing.T, mode driver.Mode, suf string, bla
 					return true
 				})
 			}
+			tokenFailures := 0
 			if len(s.VerifyTokens) != 0 && mode == driver.ModeAnnotated {
 				for _, v := range s.VerifyTokens {
 					if err := v.Verify(code, ua); err != nil {
+						tokenFailures++
 						t.Error(err)
 					}
 				}
@@ -327,19 +538,48 @@ func (s *Suite) testFixturesUAST(t *testing.T, mode driver.Mode, suf string, bla
 			un, err := marshalUAST(ua)
 			require.NoError(t, err)
 
+			rec := FixtureReport{
+				ID: s.Lang + "/" + strings.TrimSuffix(name, suffix), Mode: modeName(mode),
+				Duration: dur, NodeCount: nodeCount,
+				BlacklistHits: blacklistHits, TokenFailures: tokenFailures,
+			}
+
 			exp := s.readFixturesFile(t, fname+suf)
 			got := string(un)
 			if exp == "" {
 				s.writeFixturesFile(t, fname+suf, got)
+				s.reportFixture(rec)
 				t.Skip("no test file found - generating")
 			}
 			if !assert.ObjectsAreEqual(exp, got) {
+				diff := summarizeDiff(exp, got)
+				rec.Mismatch = &diff
+				s.reportFixture(rec)
+
+				ops := diffLines(exp, got)
+				if rendered := renderDiff(s.DiffMode, ops); rendered != "" {
+					t.Log(rendered)
+				}
+
+				update := atomic.LoadUint32(&updateAll) != 0
+				if s.UpdateInteractive != nil {
+					switch s.UpdateInteractive(fname+suf, renderUnified(ops)) {
+					case DecisionUpdate:
+						update = true
+					case DecisionUpdateAll:
+						update = true
+						atomic.StoreUint32(&updateAll, 1)
+					case DecisionQuit:
+						atomic.StoreUint32(&quit, 1)
+					}
+				}
+
 				ext := suf + gotSuffix
-				if s.UpdateUAST {
+				if update {
 					ext = suf
 				}
 				s.writeFixturesFile(t, fname+ext, got)
-				if !s.UpdateUAST {
+				if !update {
 					require.Fail(t, "unexpected UAST returned by the driver",
 						"run diff command to debug:\ndiff -d ./%s ./%s",
 						strings.TrimLeft(s.fixturesPath(fname+ext), "./"),
@@ -349,6 +589,7 @@ func (s *Suite) testFixturesUAST(t *testing.T, mode driver.Mode, suf string, bla
 					t.Skip("force update of fixtures")
 				}
 			} else {
+				s.reportFixture(rec)
 				s.deleteFixturesFile(fname + suf + gotSuffix)
 			}
 			if s.WriteViewerJSON {
@@ -0,0 +1,140 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reportEnv names the path to write the JSON test report to when
+// Suite.Report is empty.
+const reportEnv = "BBLFSH_SDK_FIXTURES_REPORT"
+
+// reportSchemaVersion is bumped whenever the Report/FixtureReport shape
+// changes in a way downstream dashboards need to know about.
+const reportSchemaVersion = 1
+
+// DiffSummary is a cheap line-based summary of an expected/actual mismatch,
+// computed without shelling out to an external `diff` binary.
+type DiffSummary struct {
+	ExpectedLines int `json:"expected_lines"`
+	GotLines      int `json:"got_lines"`
+	ChangedLines  int `json:"changed_lines"`
+}
+
+// summarizeDiff compares exp and got line by line up to the length of the
+// shorter of the two, and counts every line past that point as changed too.
+func summarizeDiff(exp, got string) DiffSummary {
+	expLines := strings.Split(exp, "\n")
+	gotLines := strings.Split(got, "\n")
+	n := len(expLines)
+	if len(gotLines) < n {
+		n = len(gotLines)
+	}
+	changed := len(expLines) - n + len(gotLines) - n
+	for i := 0; i < n; i++ {
+		if expLines[i] != gotLines[i] {
+			changed++
+		}
+	}
+	return DiffSummary{
+		ExpectedLines: len(expLines),
+		GotLines:      len(gotLines),
+		ChangedLines:  changed,
+	}
+}
+
+// FixtureReport is one fixture's record in a Report, covering a single
+// mode (native, annotated UAST or semantic UAST) of that fixture.
+type FixtureReport struct {
+	// ID stably identifies the fixture across runs, as "<lang>/<fixture>".
+	ID   string `json:"id"`
+	Mode string `json:"mode"`
+
+	Duration    time.Duration `json:"duration_ns"`
+	NativeBytes int           `json:"native_bytes,omitempty"`
+	NodeCount   int           `json:"node_count,omitempty"`
+
+	BlacklistHits map[string]int `json:"blacklist_hits,omitempty"`
+	TokenFailures int            `json:"token_failures,omitempty"`
+
+	Mismatch *DiffSummary `json:"mismatch,omitempty"`
+	Error    string       `json:"error,omitempty"`
+}
+
+// Report is the root object written out by the built-in JSON Reporter.
+type Report struct {
+	SchemaVersion int             `json:"schema_version"`
+	Lang          string          `json:"lang"`
+	Fixtures      []FixtureReport `json:"fixtures"`
+}
+
+// Reporter receives a FixtureReport for every fixture/mode combination a
+// Suite runs, so CI tooling can chart per-driver regressions across commits.
+// Fixtures expected to fail with a syntax error are not reported, nor, in
+// the uast and semantic modes, fixtures that fail to parse or transform.
+type Reporter interface {
+	Report(FixtureReport)
+}
+
+// jsonReporter is the built-in Reporter: it accumulates FixtureReports in
+// memory and writes them out as a single Report document on Flush.
+type jsonReporter struct {
+	lang string
+	path string
+
+	mu   sync.Mutex
+	recs []FixtureReport
+}
+
+func newJSONReporter(lang, path string) *jsonReporter {
+	return &jsonReporter{lang: lang, path: path}
+}
+
+func (r *jsonReporter) Report(rec FixtureReport) {
+	r.mu.Lock()
+	r.recs = append(r.recs, rec)
+	r.mu.Unlock()
+}
+
+func (r *jsonReporter) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rep := Report{
+		SchemaVersion: reportSchemaVersion,
+		Lang:          r.lang,
+		Fixtures:      r.recs,
+	}
+	data, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, data, 0666)
+}
+
+// reporter resolves the effective Reporter for the suite: an explicit
+// s.Reporter wins, otherwise a JSON reporter is built from s.Report or the
+// BBLFSH_SDK_FIXTURES_REPORT environment variable, and nil if neither is set.
+func (s *Suite) reporter() Reporter {
+	if s.Reporter != nil {
+		return s.Reporter
+	}
+	path := s.Report
+	if path == "" {
+		path = os.Getenv(reportEnv)
+	}
+	if path == "" {
+		return nil
+	}
+	return newJSONReporter(s.Lang, path)
+}
+
+// reportFixture forwards rec to s.Reporter, if one is configured.
+func (s *Suite) reportFixture(rec FixtureReport) {
+	if s.Reporter != nil {
+		s.Reporter.Report(rec)
+	}
+}
@@ -0,0 +1,27 @@
+package fixtures
+
+import "testing"
+
+func TestSummarizeDiffEqual(t *testing.T) {
+	d := summarizeDiff("a\nb\nc", "a\nb\nc")
+	if d.ChangedLines != 0 {
+		t.Fatalf("ChangedLines = %d, want 0", d.ChangedLines)
+	}
+	if d.ExpectedLines != 3 || d.GotLines != 3 {
+		t.Fatalf("unexpected line counts: %+v", d)
+	}
+}
+
+func TestSummarizeDiffMismatch(t *testing.T) {
+	d := summarizeDiff("a\nb\nc", "a\nX\nc\nd")
+	if d.ExpectedLines != 3 {
+		t.Errorf("ExpectedLines = %d, want 3", d.ExpectedLines)
+	}
+	if d.GotLines != 4 {
+		t.Errorf("GotLines = %d, want 4", d.GotLines)
+	}
+	// one changed line (b -> X) plus one extra trailing line in got
+	if d.ChangedLines != 2 {
+		t.Errorf("ChangedLines = %d, want 2", d.ChangedLines)
+	}
+}
@@ -0,0 +1,64 @@
+package fixtures
+
+import "testing"
+
+func TestParseShardFlag(t *testing.T) {
+	cases := []struct {
+		in    string
+		index int
+		total int
+		ok    bool
+	}{
+		{"", 0, 0, false},
+		{"1/4", 1, 4, true},
+		{"0/1", 0, 1, true},
+		{"4/4", 0, 0, false}, // index out of range
+		{"-1/4", 0, 0, false},
+		{"x/4", 0, 0, false},
+		{"1/x", 0, 0, false},
+		{"1/0", 0, 0, false},
+		{"1", 0, 0, false},
+	}
+	for _, c := range cases {
+		idx, total, ok := parseShardFlag(c.in)
+		if ok != c.ok {
+			t.Errorf("parseShardFlag(%q): ok = %v, want %v", c.in, ok, c.ok)
+			continue
+		}
+		if ok && (idx != c.index || total != c.total) {
+			t.Errorf("parseShardFlag(%q) = %d/%d, want %d/%d", c.in, idx, total, c.index, c.total)
+		}
+	}
+}
+
+func TestInShardZeroValueMatchesEverything(t *testing.T) {
+	if !inShard(Shard{}, "foo.py") {
+		t.Fatal("zero-value Shard should match every filename")
+	}
+}
+
+func TestInShardPartitionsFixtures(t *testing.T) {
+	const total = 4
+	names := []string{"a.py", "b.py", "c.py", "d.py", "e.py"}
+	for _, name := range names {
+		matches := 0
+		for i := 0; i < total; i++ {
+			if inShard(Shard{Index: i, Total: total}, name) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			t.Errorf("%q matched %d of %d shards, want exactly 1", name, matches, total)
+		}
+	}
+}
+
+func TestInShardStable(t *testing.T) {
+	sh := Shard{Index: 2, Total: 4}
+	first := inShard(sh, "stable.py")
+	for i := 0; i < 10; i++ {
+		if inShard(sh, "stable.py") != first {
+			t.Fatal("inShard is not stable across repeated calls")
+		}
+	}
+}
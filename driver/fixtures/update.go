@@ -0,0 +1,17 @@
+package fixtures
+
+// UpdateDecision is the outcome of an interactive golden-file update prompt.
+type UpdateDecision int
+
+const (
+	DecisionSkip      UpdateDecision = iota // "n": leave the golden file as-is
+	DecisionUpdate                          // "y": update this fixture
+	DecisionUpdateAll                       // "all": update this and every later mismatch too
+	DecisionQuit                            // "quit": stop the run without updating
+)
+
+// UpdatePrompter shows a fixture's diff and asks the user what to do with
+// it. It is the hook `bblfsh-sdk test -update-interactive` uses to drive an
+// interactive y/n/all/quit prompt, without this package depending on any
+// terminal/readline library itself.
+type UpdatePrompter func(fixture, diff string) UpdateDecision